@@ -0,0 +1,111 @@
+// Copyright (c) 2020 Tailscale Inc & AUTHORS All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package hostinfo answers questions about the host environment that
+// Tailscale is running on, for inclusion in tailcfg.Hostinfo.
+package hostinfo
+
+import (
+	"sync"
+
+	"tailscale.com/tailcfg"
+)
+
+// Per-OS detection hooks. Each is populated by that OS's init() (see
+// hostinfo_linux.go, etc) and left nil on platforms that don't implement
+// the corresponding detection, in which case New leaves the field zero.
+var (
+	osVersion      func() string
+	packageType    func() string
+	distroName     func() string
+	distroVersion  func() string
+	distroCodeName func() string
+	linuxFWFill    func() *tailcfg.LinuxFW
+
+	// warnings reports human-readable host configuration problems, e.g.
+	// "IPv4 forwarding is disabled".
+	warnings func() []string
+
+	// hypervisor, container, and cloudProvider report the detected
+	// virtualization/container/cloud environment, or "" if none.
+	hypervisor    func() string
+	container     func() string
+	cloudProvider func() string
+
+	// goBuildInfoFill reports the Go toolchain version, main module
+	// path+version, and VCS revision embedded in the running binary.
+	goBuildInfoFill func() (goVersion, goModule, vcsRevision string)
+)
+
+var deviceModelMu sync.Mutex
+var deviceModel string
+
+// SetDeviceModel records the detected device model (e.g. a Synology or
+// Raspberry Pi variant) for inclusion in the next New call's result.
+func SetDeviceModel(model string) {
+	deviceModelMu.Lock()
+	defer deviceModelMu.Unlock()
+	deviceModel = model
+}
+
+// New returns a Hostinfo populated with whatever this package was able to
+// detect about the current host: OS/distro identity, package provenance,
+// Linux firewall state, virtualization, and Go build info.
+func New() *tailcfg.Hostinfo {
+	hi := new(tailcfg.Hostinfo)
+	if osVersion != nil {
+		hi.OSVersion = osVersion()
+	}
+	if packageType != nil {
+		hi.PackageType = packageType()
+	}
+	if distroName != nil {
+		hi.DistroName = distroName()
+	}
+	if distroVersion != nil {
+		hi.DistroVersion = distroVersion()
+	}
+	if distroCodeName != nil {
+		hi.DistroCodeName = distroCodeName()
+	}
+	if linuxFWFill != nil {
+		hi.LinuxFW = linuxFWFill()
+	}
+	if warnings != nil {
+		hi.Warnings = warnings()
+	}
+	if hypervisor != nil {
+		hi.Hypervisor = hypervisor()
+	}
+	if container != nil {
+		hi.Container = container()
+	}
+	if cloudProvider != nil {
+		hi.CloudProvider = cloudProvider()
+	}
+	if goBuildInfoFill != nil {
+		hi.GoVersion, hi.GoModule, hi.VCSRevision = goBuildInfoFill()
+	}
+	deviceModelMu.Lock()
+	hi.DeviceModel = deviceModel
+	deviceModelMu.Unlock()
+	return hi
+}
+
+// lazyAtomicValue lazily computes and caches the result of *f the first
+// time Get is called.
+type lazyAtomicValue[T any] struct {
+	once sync.Once
+	v    T
+	f    *func() T
+}
+
+func (l *lazyAtomicValue[T]) Get() T {
+	l.once.Do(func() { l.v = (*l.f)() })
+	return l.v
+}
+
+// ptrTo returns a pointer to f, for initializing a lazyAtomicValue from a
+// top-level function value in a var block.
+func ptrTo[T any](f func() T) *func() T { return &f }
@@ -9,12 +9,17 @@ package hostinfo
 
 import (
 	"bytes"
+	"context"
+	"debug/buildinfo"
 	"errors"
+	"fmt"
 	"os"
 	"os/exec"
 	"regexp"
+	"strconv"
 	"strings"
 	"syscall"
+	"time"
 
 	"golang.org/x/sys/unix"
 	"tailscale.com/tailcfg"
@@ -34,6 +39,11 @@ func init() {
 		SetDeviceModel(v)
 	}
 	linuxFWFill = linuxFW
+	warnings = linuxWarnings
+	hypervisor = linuxHypervisor
+	container = linuxContainer
+	cloudProvider = linuxCloudProvider
+	goBuildInfoFill = linuxGoBuildInfo
 }
 
 var (
@@ -163,19 +173,187 @@ func linuxVersionMeta() (meta versionMeta) {
 	case distro.OpenWrt:
 		meta.DistroVersion = m["DISTRIB_RELEASE"]
 	}
+
+	// /etc/os-release is missing entirely, or present but useless (no ID):
+	// minimal images, old CentOS/RHEL, and various appliances only leave
+	// behind one of a handful of legacy release files. Walk an ordered
+	// fallback table, Ansible OSDIST_LIST-style, and take the first hit.
+	if id == "" {
+		if fb, ok := tryReleaseFileFallbacks(); ok {
+			if meta.DistroName == "" || meta.DistroName == string(dist) {
+				meta.DistroName = fb.DistroName
+			}
+			if meta.DistroVersion == "" {
+				meta.DistroVersion = fb.DistroVersion
+			}
+		}
+	}
 	return
 }
 
+// releaseFileFallbacks is an ordered table of legacy/minimal-image release
+// files and the canonical distro name each one implies, probed when
+// /etc/os-release doesn't exist or has no ID. Modeled after the probing
+// order of Ansible's OSDIST_LIST.
+var releaseFileFallbacks = []struct {
+	path string
+	name string
+}{
+	{"/etc/oracle-release", "oracle"},
+	{"/etc/redhat-release", "redhat"}, // CentOS/RHEL 5 & 6, and derivatives without their own file
+	{"/etc/slackware-version", "slackware"},
+	{"/etc/vmware-release", "vmware"}, // VMware ESX/Photon appliances
+	{"/etc/photon-release", "photon"},
+	{"/etc/alpine-release", "alpine"},
+	{"/etc/arch-release", "arch"},
+	{"/etc/gentoo-release", "gentoo"},
+	{"/etc/SuSE-release", "suse"},
+	{"/etc/system-release", ""}, // shared by RHEL/CentOS/Fedora/Amazon Linux; name sniffed from content, see systemReleaseDistroName
+}
+
+// releaseVersionRE pulls a dotted (or bare) version number out of a
+// free-form release string, e.g. "CentOS release 6.10 (Final)" or
+// "Slackware 14.2".
+var releaseVersionRE = regexp.MustCompile(`\d+(\.\d+)*`)
+
+// tryReleaseFileFallbacks probes releaseFileFallbacks in order and returns
+// the distro name/version derived from the first release file that exists
+// and is non-empty.
+func tryReleaseFileFallbacks() (meta versionMeta, ok bool) {
+	for _, rf := range releaseFileFallbacks {
+		slurp, err := os.ReadFile(rf.path)
+		if err != nil {
+			continue
+		}
+		name := rf.name
+		if name == "" {
+			name = systemReleaseDistroName(string(slurp))
+		}
+		if meta, ok = parseReleaseFileContent(name, string(slurp)); ok {
+			return meta, true
+		}
+	}
+	return versionMeta{}, false
+}
+
+// systemReleaseDistroName sniffs the free-form content of /etc/system-release
+// to tell which RHEL-family distro wrote it. Unlike the other legacy release
+// files, that one path is shared verbatim by RHEL, CentOS, Fedora, and Amazon
+// Linux; each spells its own name into the text, e.g. "Amazon Linux AMI
+// release 2018.03" or "CentOS Linux release 7.9.2009 (Core)".
+func systemReleaseDistroName(content string) string {
+	s := strings.ToLower(content)
+	switch {
+	case strings.Contains(s, "amazon"):
+		return "amzn"
+	case strings.Contains(s, "centos"):
+		return "centos"
+	case strings.Contains(s, "fedora"):
+		return "fedora"
+	default:
+		// Plain "Red Hat Enterprise Linux ... release N" and unrecognized
+		// derivatives both fall back to the generic family name.
+		return "redhat"
+	}
+}
+
+// parseReleaseFileContent turns the raw contents of a legacy release file
+// (e.g. "CentOS release 6.10 (Final)\n" or "Slackware 14.2\n") into a
+// versionMeta, given the canonical distro name releaseFileFallbacks
+// associated with that file. It's split out from tryReleaseFileFallbacks
+// so it can be tested against golden file contents without touching disk.
+func parseReleaseFileContent(distroName, content string) (meta versionMeta, ok bool) {
+	s := strings.TrimSpace(content)
+	if s == "" {
+		return versionMeta{}, false
+	}
+	meta.DistroName = distroName
+	meta.DistroVersion = releaseVersionRE.FindString(s)
+	if meta.DistroVersion == "" {
+		meta.DistroVersion = s
+	}
+	return meta, true
+}
+
 func packageTypeLinux() string {
+	return lazyPackageType.Get()
+}
+
+// packageQueryTimeout bounds how long we'll wait on a package-manager
+// lookup (dpkg, rpm, etc.) to identify the running binary's provenance;
+// these can be slow on a cold page cache and we'd rather move on to the
+// next query (and, if none answers, report "source") than block hostinfo
+// collection.
+const packageQueryTimeout = 2 * time.Second
+
+// detectPackageType figures out how the running tailscaled binary got onto
+// this machine: a distro package, a container image, a universal package
+// format, or a binary someone built themselves. It's the Linux analogue of
+// "apt source" or "which package owns this file", done generically enough
+// to cover the major packaging ecosystems.
+func detectPackageType() string {
 	// Report whether this is in a snap.
 	// See https://snapcraft.io/docs/environment-variables
 	// We just look at two somewhat arbitrarily.
 	if os.Getenv("SNAP_NAME") != "" && os.Getenv("SNAP") != "" {
 		return "snap"
 	}
-	return ""
+	if os.Getenv("FLATPAK_ID") != "" {
+		return "flatpak"
+	}
+	if os.Getenv("APPIMAGE") != "" {
+		return "appimage"
+	}
+
+	exe, err := os.Readlink("/proc/self/exe")
+	if err != nil {
+		return ""
+	}
+	switch {
+	case strings.HasPrefix(exe, "/nix/store/"):
+		return "nix"
+	case strings.Contains(exe, "/.linuxbrew/"), strings.Contains(exe, "/Homebrew/"):
+		return "homebrew"
+	case strings.HasSuffix(exe, " (deleted)"):
+		// The on-disk binary was replaced or removed after exec; most
+		// commonly an overlayfs/container image where the file path no
+		// longer resolves cleanly. Fall through to container detection.
+	}
+
+	if rt := linuxContainer(); rt != "" {
+		return rt
+	}
+
+	for _, q := range packageQueries {
+		if _, err := exec.LookPath(q.bin); err != nil {
+			continue
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), packageQueryTimeout)
+		out, err := exec.CommandContext(ctx, q.bin, append(q.args, exe)...).Output()
+		cancel()
+		if err == nil && len(out) > 0 {
+			return q.name
+		}
+	}
+
+	return "source"
+}
+
+// packageQueries is the ordered list of package-manager "who owns this
+// file" commands we try against the running binary's path.
+var packageQueries = []struct {
+	name string
+	bin  string
+	args []string
+}{
+	{"deb", "dpkg", []string{"-S"}},
+	{"rpm", "rpm", []string{"-qf"}},
+	{"apk", "apk", []string{"info", "--who-owns"}},
+	{"pacman", "pacman", []string{"-Qo"}},
 }
 
+var lazyPackageType = &lazyAtomicValue[string]{f: ptrTo(detectPackageType)}
+
 func linuxFW() *tailcfg.LinuxFW {
 	ret := &tailcfg.LinuxFW{
 		BinInfo: make(map[string]tailcfg.LinuxFWBinInfo),
@@ -194,6 +372,92 @@ func linuxFW() *tailcfg.LinuxFW {
 	return ret
 }
 
+// minRmemMaxBytes is the smallest net.core.rmem_max we consider adequate for
+// WireGuard's UDP receive buffer; below this, the kernel silently truncates
+// SO_RCVBUF requests and throughput suffers under load.
+const minRmemMaxBytes = 2 << 20 // 2MB
+
+// linuxWarnings inspects kernel and cgroup state for conditions that are
+// known to degrade or break Tailscale networking, and returns a list of
+// human-readable warnings, Docker "docker info"-style, for the control
+// plane and `tailscale debug hostinfo` to surface.
+func linuxWarnings() []string {
+	var ws []string
+	add := func(format string, args ...any) {
+		ws = append(ws, fmt.Sprintf(format, args...))
+	}
+
+	if readSysctlInt("/proc/sys/net/ipv4/ip_forward") == 0 {
+		add("IPv4 forwarding is disabled")
+	}
+	if readSysctlInt("/proc/sys/net/ipv6/conf/all/forwarding") == 0 {
+		add("IPv6 forwarding is disabled")
+	}
+	if readSysctlInt("/proc/sys/net/bridge/bridge-nf-call-iptables") == 0 {
+		add("bridge-nf-call-iptables is disabled")
+	}
+	if readSysctlInt("/proc/sys/net/bridge/bridge-nf-call-ip6tables") == 0 {
+		add("bridge-nf-call-ip6tables is disabled")
+	}
+	if !nfConntrackLoaded() {
+		add("nf_conntrack is not loaded")
+	}
+	if rpFilterIsStrict() {
+		add("rp_filter is set to strict mode, which can drop Tailscale traffic on multi-homed hosts")
+	}
+	if n := readSysctlInt("/proc/sys/net/core/rmem_max"); n > 0 && n < minRmemMaxBytes {
+		add("net.core.rmem_max (%d) is small and may limit WireGuard throughput", n)
+	}
+	if usingCgroupsV1() {
+		add("host is using cgroup v1")
+	}
+	return ws
+}
+
+// readSysctlInt reads an integer sysctl knob exposed as a /proc/sys file
+// and returns -1 if it can't be read or parsed.
+func readSysctlInt(path string) int {
+	slurp, err := os.ReadFile(path)
+	if err != nil {
+		return -1
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(string(slurp)))
+	if err != nil {
+		return -1
+	}
+	return n
+}
+
+// nfConntrackLoaded reports whether the nf_conntrack module appears to be
+// loaded, either built-in (showing up in /proc/net) or as a module.
+func nfConntrackLoaded() bool {
+	if _, err := os.Stat("/proc/net/nf_conntrack"); err == nil {
+		return true
+	}
+	var loaded bool
+	lineread.File("/proc/modules", func(line []byte) error {
+		if bytes.HasPrefix(line, []byte("nf_conntrack ")) {
+			loaded = true
+		}
+		return nil
+	})
+	return loaded
+}
+
+// rpFilterIsStrict reports whether rp_filter is set to strict (1) mode in
+// net.ipv4.conf.all, which drops asymmetrically-routed packets and
+// can interfere with subnet routing.
+func rpFilterIsStrict() bool {
+	return readSysctlInt("/proc/sys/net/ipv4/conf/all/rp_filter") == 1
+}
+
+// usingCgroupsV1 reports whether the host is using the v1 cgroup
+// hierarchy, detected by the absence of the unified v2 controller file.
+func usingCgroupsV1() bool {
+	_, err := os.Stat("/sys/fs/cgroup/cgroup.controllers")
+	return err != nil
+}
+
 func detectFwType(ty *tailcfg.LinuxFWTypeInfo, n int, err error) {
 	ty.NumRules = n
 	if err == nil {
@@ -244,3 +508,200 @@ func detectBinary(name string) (ret tailcfg.LinuxFWBinInfo) {
 	}
 	return
 }
+
+// dmiSignatures maps substrings found in /sys/class/dmi/id/{sys_vendor,
+// product_name,bios_vendor} to the hypervisor or cloud platform they imply.
+// Checked in order, first match wins.
+var dmiSignatures = []struct {
+	substr string
+	name   string
+}{
+	{"amazon ec2", "ec2"},
+	{"google compute engine", "gce"},
+	{"kvm", "kvm"},
+	{"qemu", "qemu"},
+	{"vmware", "vmware"},
+	{"virtualbox", "virtualbox"},
+	{"xen", "xen"},
+	{"bochs", "bochs"},
+}
+
+var dmiFiles = []string{
+	"/sys/class/dmi/id/sys_vendor",
+	"/sys/class/dmi/id/product_name",
+	"/sys/class/dmi/id/bios_vendor",
+}
+
+// linuxHypervisor reports the hypervisor the current kernel is running
+// under, if any, mirroring (a subset of) what systemd-detect-virt computes.
+// It returns "" on bare metal.
+func linuxHypervisor() string {
+	// "Microsoft Corporation" alone in sys_vendor/bios_vendor isn't
+	// sufficient: real Microsoft-branded hardware (Surface, many OEM
+	// boards) reports it too. Hyper-V guests additionally report
+	// product_name as "Virtual Machine", so require both like
+	// linuxCloudProvider does for Azure.
+	if sysVendor, err := os.ReadFile("/sys/class/dmi/id/sys_vendor"); err == nil {
+		if productName, err := os.ReadFile("/sys/class/dmi/id/product_name"); err == nil {
+			sv, pn := strings.ToLower(string(sysVendor)), strings.ToLower(string(productName))
+			if strings.Contains(sv, "microsoft corporation") && strings.Contains(pn, "virtual machine") {
+				return "hyperv"
+			}
+		}
+	}
+
+	for _, f := range dmiFiles {
+		slurp, err := os.ReadFile(f)
+		if err != nil {
+			continue
+		}
+		s := strings.ToLower(string(slurp))
+		for _, sig := range dmiSignatures {
+			if strings.Contains(s, sig.substr) {
+				return sig.name
+			}
+		}
+	}
+
+	if _, err := os.Stat("/proc/xen"); err == nil {
+		return "xen"
+	}
+
+	if b, err := os.ReadFile("/proc/device-tree/hypervisor/compatible"); err == nil && len(b) > 0 {
+		return "kvm"
+	}
+
+	var hasHypervisorFlag bool
+	lineread.File("/proc/cpuinfo", func(line []byte) error {
+		if bytes.HasPrefix(line, []byte("flags")) && bytes.Contains(line, []byte("hypervisor")) {
+			hasHypervisorFlag = true
+		}
+		return nil
+	})
+	if hasHypervisorFlag {
+		return "vm"
+	}
+
+	return ""
+}
+
+// linuxContainer reports the container runtime the current process is
+// running under ("docker", "podman", "containerd", "lxc", etc.), or "" if
+// not containerized.
+func linuxContainer() string {
+	if _, err := os.Stat("/.dockerenv"); err == nil {
+		return "docker"
+	}
+	if _, err := os.Stat("/run/.containerenv"); err == nil {
+		return "podman"
+	}
+
+	if b, err := os.ReadFile("/proc/1/environ"); err == nil {
+		for _, field := range bytes.Split(b, []byte{0}) {
+			if v, ok := bytes.CutPrefix(field, []byte("container=")); ok && len(v) > 0 {
+				return string(v)
+			}
+		}
+	}
+
+	var rt string
+	lineread.File("/proc/1/cgroup", func(line []byte) error {
+		s := string(line)
+		switch {
+		case strings.Contains(s, "docker"):
+			rt = "docker"
+		case strings.Contains(s, "containerd"):
+			rt = "containerd"
+		case strings.Contains(s, "crio"):
+			rt = "cri-o"
+		case strings.Contains(s, "kubepods"):
+			if rt == "" {
+				rt = "kubernetes"
+			}
+		case strings.Contains(s, "lxc"):
+			rt = "lxc"
+		}
+		return nil
+	})
+	if rt != "" {
+		return rt
+	}
+
+	// A container's init process is rarely PID 1 from the host's point of
+	// view; NSpid having more than one entry means we're in a PID namespace.
+	if b, err := os.ReadFile("/proc/self/status"); err == nil {
+		for _, line := range bytes.Split(b, []byte("\n")) {
+			if rest, ok := bytes.CutPrefix(line, []byte("NSpid:")); ok {
+				if len(bytes.Fields(rest)) > 1 {
+					return "container"
+				}
+			}
+		}
+	}
+
+	return ""
+}
+
+// linuxCloudProvider reports the cloud platform the host is running on
+// ("aws", "gcp", "azure"), derived from the same DMI files that
+// linuxHypervisor reads, or "" if not running on a recognized cloud.
+func linuxCloudProvider() string {
+	for _, f := range dmiFiles {
+		slurp, err := os.ReadFile(f)
+		if err != nil {
+			continue
+		}
+		s := strings.ToLower(string(slurp))
+		switch {
+		case strings.Contains(s, "amazon"):
+			return "aws"
+		case strings.Contains(s, "google"):
+			return "gcp"
+		}
+	}
+	// Azure doesn't put an identifiable string in any single DMI file: the
+	// "Microsoft Corporation" vendor string alone also matches real
+	// Microsoft-branded hardware, so (as in linuxHypervisor) we require it
+	// together with product_name being "Virtual Machine" from a separate
+	// file.
+	if sysVendor, err := os.ReadFile("/sys/class/dmi/id/sys_vendor"); err == nil {
+		if productName, err := os.ReadFile("/sys/class/dmi/id/product_name"); err == nil {
+			sv, pn := strings.ToLower(string(sysVendor)), strings.ToLower(string(productName))
+			if strings.Contains(sv, "microsoft corporation") && strings.Contains(pn, "virtual machine") {
+				return "azure"
+			}
+		}
+	}
+	return ""
+}
+
+// linuxGoBuildInfo reports the Go toolchain version, main module path and
+// version, and VCS revision embedded in the running tailscaled binary. It
+// reads the build info the Go linker embeds in every binary (the same data
+// `go version -m` prints) rather than re-deriving it from ELF sections by
+// hand. All three fields are "" if the running binary can't be opened or
+// isn't a Go binary, which shouldn't normally happen for tailscaled itself.
+func linuxGoBuildInfo() (goVersion, goModule, vcsRevision string) {
+	exe, err := os.Executable()
+	if err != nil {
+		return
+	}
+	bi, err := buildinfo.ReadFile(exe)
+	if err != nil {
+		return
+	}
+	goVersion = bi.GoVersion
+	if bi.Main.Path != "" {
+		goModule = bi.Main.Path
+		if bi.Main.Version != "" && bi.Main.Version != "(devel)" {
+			goModule += "@" + bi.Main.Version
+		}
+	}
+	for _, s := range bi.Settings {
+		if s.Key == "vcs.revision" {
+			vcsRevision = s.Value
+			break
+		}
+	}
+	return
+}
@@ -0,0 +1,128 @@
+// Copyright (c) 2020 Tailscale Inc & AUTHORS All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build linux && !android
+// +build linux,!android
+
+package hostinfo
+
+import (
+	"os"
+	"testing"
+)
+
+func TestParseReleaseFileContent(t *testing.T) {
+	tests := []struct {
+		goldenFile string // under testdata/
+		distroName string
+		wantOK     bool
+		wantMeta   versionMeta
+	}{
+		{
+			goldenFile: "oracle-release",
+			distroName: "oracle",
+			wantOK:     true,
+			wantMeta:   versionMeta{DistroName: "oracle", DistroVersion: "7.9"},
+		},
+		{
+			goldenFile: "redhat-release-centos6",
+			distroName: "redhat",
+			wantOK:     true,
+			wantMeta:   versionMeta{DistroName: "redhat", DistroVersion: "6.10"},
+		},
+		{
+			goldenFile: "redhat-release-rhel5",
+			distroName: "redhat",
+			wantOK:     true,
+			wantMeta:   versionMeta{DistroName: "redhat", DistroVersion: "5.11"},
+		},
+		{
+			goldenFile: "slackware-version",
+			distroName: "slackware",
+			wantOK:     true,
+			wantMeta:   versionMeta{DistroName: "slackware", DistroVersion: "14.2"},
+		},
+		{
+			goldenFile: "vmware-release",
+			distroName: "vmware",
+			wantOK:     true,
+			wantMeta:   versionMeta{DistroName: "vmware", DistroVersion: "4.1.0"},
+		},
+		{
+			goldenFile: "photon-release",
+			distroName: "photon",
+			wantOK:     true,
+			wantMeta:   versionMeta{DistroName: "photon", DistroVersion: "4.0"},
+		},
+		{
+			goldenFile: "alpine-release",
+			distroName: "alpine",
+			wantOK:     true,
+			wantMeta:   versionMeta{DistroName: "alpine", DistroVersion: "3.18.4"},
+		},
+		{
+			// Arch is a rolling release; /etc/arch-release is
+			// conventionally empty, which should be treated as "no
+			// usable fallback" rather than an empty-string version.
+			goldenFile: "arch-release",
+			distroName: "arch",
+			wantOK:     false,
+		},
+		{
+			goldenFile: "gentoo-release",
+			distroName: "gentoo",
+			wantOK:     true,
+			wantMeta:   versionMeta{DistroName: "gentoo", DistroVersion: "2.15"},
+		},
+		{
+			goldenFile: "SuSE-release",
+			distroName: "suse",
+			wantOK:     true,
+			wantMeta:   versionMeta{DistroName: "suse", DistroVersion: "11"},
+		},
+		{
+			goldenFile: "system-release-amzn",
+			distroName: "amzn",
+			wantOK:     true,
+			wantMeta:   versionMeta{DistroName: "amzn", DistroVersion: "2018.03"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.goldenFile, func(t *testing.T) {
+			content, err := os.ReadFile("testdata/" + tt.goldenFile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			meta, ok := parseReleaseFileContent(tt.distroName, string(content))
+			if ok != tt.wantOK {
+				t.Fatalf("ok = %v; want %v (meta=%+v)", ok, tt.wantOK, meta)
+			}
+			if !tt.wantOK {
+				return
+			}
+			if meta != tt.wantMeta {
+				t.Errorf("parseReleaseFileContent(%q, ...) = %+v; want %+v", tt.distroName, meta, tt.wantMeta)
+			}
+		})
+	}
+}
+
+func TestSystemReleaseDistroName(t *testing.T) {
+	tests := []struct {
+		content string
+		want    string
+	}{
+		{"Amazon Linux AMI release 2018.03\n", "amzn"},
+		{"Amazon Linux release 2 (Karoo)\n", "amzn"},
+		{"CentOS Linux release 7.9.2009 (Core)\n", "centos"},
+		{"Fedora release 32 (Thirty Two)\n", "fedora"},
+		{"Red Hat Enterprise Linux Server release 7.9 (Maipo)\n", "redhat"},
+	}
+	for _, tt := range tests {
+		if got := systemReleaseDistroName(tt.content); got != tt.want {
+			t.Errorf("systemReleaseDistroName(%q) = %q; want %q", tt.content, got, tt.want)
+		}
+	}
+}
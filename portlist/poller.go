@@ -0,0 +1,37 @@
+// Copyright (c) 2020 Tailscale Inc & AUTHORS All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package portlist
+
+// Poller scans the machine's listening sockets on demand.
+type Poller struct {
+	// IncludeProcessInfo, if true, additionally populates each returned
+	// Port's PID, Exe, Cmdline, UID, Container, and GoModule fields, and
+	// preserves multiple listeners on the same (Proto, Port) from
+	// different PIDs instead of collapsing them to one. This costs a
+	// handful of extra /proc reads (and a build-info parse) per
+	// listening process, so it's opt-in.
+	IncludeProcessInfo bool
+}
+
+// Poll returns the current list of listening ports on the machine, sorted
+// and deduplicated.
+func (p *Poller) Poll() (List, error) {
+	ports, err := listPorts()
+	if err != nil {
+		return nil, err
+	}
+	if p.IncludeProcessInfo {
+		for i := range ports {
+			addProcessInfo(&ports[i])
+		}
+	} else {
+		// listPorts may have attributed sockets to PIDs to name their
+		// processes; PID itself is only reported with IncludeProcessInfo.
+		for i := range ports {
+			ports[i].PID = 0
+		}
+	}
+	return sortAndDedup(ports, p.IncludeProcessInfo), nil
+}
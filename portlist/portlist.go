@@ -20,6 +20,16 @@ type Port struct {
 	Port    uint16 // port number
 	Process string // optional process name, if found
 
+	// The following fields are only populated when a Poller is constructed
+	// with the IncludeProcessInfo option, since they cost an extra handful
+	// of syscalls per port that most callers don't need.
+	PID       int    // process ID that owns the listening socket, or 0 if unknown
+	Exe       string // resolved target of /proc/<PID>/exe, or "" if unknown
+	Cmdline   string // /proc/<PID>/cmdline, space-joined and truncated, or ""
+	UID       uint32 // owning user ID of the process, or 0 if unknown
+	Container string // container ID or pod UID the process belongs to, or ""
+	GoModule  string // "module@version" parsed from the binary's embedded build info, or "" if not a Go binary
+
 	inode string // OS-specific; "socket:[165614651]" on Linux
 }
 
@@ -50,7 +60,9 @@ func (a *Port) lessThan(b *Port) bool {
 	} else if a.Process > b.Process {
 		return false
 	}
-	return false
+
+	// PID only disambiguates further; it's zero for most callers.
+	return a.PID < b.PID
 }
 
 func (a List) sameInodes(b List) bool {
@@ -60,7 +72,8 @@ func (a List) sameInodes(b List) bool {
 	for i := range a {
 		if a[i].Proto != b[i].Proto ||
 			a[i].Port != b[i].Port ||
-			a[i].inode != b[i].inode {
+			a[i].inode != b[i].inode ||
+			a[i].PID != b[i].PID {
 			return false
 		}
 	}
@@ -70,15 +83,34 @@ func (a List) sameInodes(b List) bool {
 func (pl List) String() string {
 	var sb strings.Builder
 	for _, v := range pl {
-		fmt.Fprintf(&sb, "%-3s %5d %-17s %#v\n",
-			v.Proto, v.Port, v.inode, v.Process)
+		fmt.Fprintf(&sb, "%-3s %5d %-17s %#v", v.Proto, v.Port, v.inode, v.Process)
+		if v.PID != 0 {
+			fmt.Fprintf(&sb, " pid=%d", v.PID)
+		}
+		if v.Exe != "" {
+			fmt.Fprintf(&sb, " exe=%q", v.Exe)
+		}
+		if v.Container != "" {
+			fmt.Fprintf(&sb, " container=%q", v.Container)
+		}
+		if v.GoModule != "" {
+			fmt.Fprintf(&sb, " gomodule=%q", v.GoModule)
+		}
+		sb.WriteByte('\n')
 	}
 	return strings.TrimRight(sb.String(), "\n")
 }
 
-// sortAndDedup sorts ps in place (by Port.lessThan) and then returns
-// a subset of it with duplicate (Proto, Port) removed.
-func sortAndDedup(ps List) List {
+// sortAndDedup sorts ps in place (by Port.lessThan) and then returns a
+// subset of it with duplicate (Proto, Port) removed, keeping only the
+// first (lowest-sorting) listener for each.
+//
+// If keepAllPIDs is true, listeners on the same (Proto, Port) from
+// different PIDs are all kept instead of collapsed to one; this is used
+// when the Poller's IncludeProcessInfo option is enabled, since "port 80
+// is nginx in container X and also a stray python REPL" is meaningfully
+// different from either alone.
+func sortAndDedup(ps List, keepAllPIDs bool) List {
 	sort.Slice(ps, func(i, j int) bool {
 		return (&ps[i]).lessThan(&ps[j])
 	})
@@ -86,6 +118,9 @@ func sortAndDedup(ps List) List {
 	var last Port
 	for _, p := range ps {
 		protoPort := Port{Proto: p.Proto, Port: p.Port}
+		if keepAllPIDs {
+			protoPort.PID = p.PID
+		}
 		if last == protoPort {
 			continue
 		}
@@ -0,0 +1,148 @@
+// Copyright (c) 2020 Tailscale Inc & AUTHORS All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build linux && !android
+// +build linux,!android
+
+package portlist
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// tcpListenState is the /proc/net/tcp{,6} "st" field value for a socket in
+// LISTEN state. See linux/include/net/tcp_states.h.
+const tcpListenState = "0A"
+
+// procNetFiles lists the /proc/net tables we scan, and the protocol each
+// implies. UDP has no listen state to filter on; any bound socket counts.
+var procNetFiles = []struct {
+	path  string
+	proto string
+}{
+	{"/proc/net/tcp", "tcp"},
+	{"/proc/net/tcp6", "tcp"},
+	{"/proc/net/udp", "udp"},
+	{"/proc/net/udp6", "udp"},
+}
+
+// listPorts returns the listening ports found in /proc/net, with PID (and
+// process name) filled in where we could attribute the socket's inode to a
+// process via /proc/<pid>/fd.
+func listPorts() (List, error) {
+	byInode := make(map[string]Port)
+	for _, f := range procNetFiles {
+		if err := scanProcNet(f.path, f.proto, byInode); err != nil {
+			return nil, err
+		}
+	}
+	if len(byInode) == 0 {
+		return nil, nil
+	}
+
+	pidForInode := pidsOwningInodes(byInode)
+
+	var ret List
+	for inode, p := range byInode {
+		if pid, ok := pidForInode[inode]; ok {
+			p.PID = pid
+			p.Process = processName(pid)
+		}
+		ret = append(ret, p)
+	}
+	return ret, nil
+}
+
+// scanProcNet parses one /proc/net/{tcp,tcp6,udp,udp6} table, adding an
+// entry to byInode for each listening (TCP) or bound (UDP) socket found.
+func scanProcNet(path, proto string, byInode map[string]Port) error {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil // e.g. no IPv6 support in this kernel
+		}
+		return err
+	}
+	defer f.Close()
+
+	sc := bufio.NewScanner(f)
+	sc.Scan() // header line
+	for sc.Scan() {
+		fields := strings.Fields(sc.Text())
+		if len(fields) < 10 {
+			continue
+		}
+		localAddr, state, inode := fields[1], fields[3], fields[9]
+		if proto == "tcp" && state != tcpListenState {
+			continue
+		}
+		idx := strings.IndexByte(localAddr, ':')
+		if idx == -1 {
+			continue
+		}
+		portNum, err := strconv.ParseUint(localAddr[idx+1:], 16, 16)
+		if err != nil {
+			continue
+		}
+		byInode[inode] = Port{
+			Proto: proto,
+			Port:  uint16(portNum),
+			inode: fmt.Sprintf("socket:[%s]", inode),
+		}
+	}
+	return sc.Err()
+}
+
+// pidsOwningInodes walks /proc/<pid>/fd for every running process, looking
+// for "socket:[<inode>]" symlinks matching one of want's keys, and returns
+// the first PID found owning each such inode.
+func pidsOwningInodes(want map[string]Port) map[string]int {
+	ret := make(map[string]int)
+	procEntries, err := os.ReadDir("/proc")
+	if err != nil {
+		return ret
+	}
+	for _, pe := range procEntries {
+		pid, err := strconv.Atoi(pe.Name())
+		if err != nil {
+			continue
+		}
+		fds, err := os.ReadDir(fmt.Sprintf("/proc/%d/fd", pid))
+		if err != nil {
+			continue // process exited, or not ours to read
+		}
+		for _, fd := range fds {
+			link, err := os.Readlink(fmt.Sprintf("/proc/%d/fd/%s", pid, fd.Name()))
+			if err != nil {
+				continue
+			}
+			inode, ok := strings.CutPrefix(link, "socket:[")
+			if !ok {
+				continue
+			}
+			inode = strings.TrimSuffix(inode, "]")
+			if _, wanted := want[inode]; !wanted {
+				continue
+			}
+			if _, already := ret[inode]; !already {
+				ret[inode] = pid
+			}
+		}
+	}
+	return ret
+}
+
+// processName returns the short process name (as /proc/<pid>/comm reports
+// it) for pid, or "" if it can't be read.
+func processName(pid int) string {
+	b, err := os.ReadFile(fmt.Sprintf("/proc/%d/comm", pid))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(b))
+}
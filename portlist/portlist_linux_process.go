@@ -0,0 +1,216 @@
+// Copyright (c) 2020 Tailscale Inc & AUTHORS All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build linux && !android
+// +build linux,!android
+
+package portlist
+
+import (
+	"bytes"
+	"container/list"
+	"debug/buildinfo"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"syscall"
+)
+
+// maxCmdline is the number of bytes of /proc/<pid>/cmdline we keep; command
+// lines can be arbitrarily long and we only need enough to identify the
+// process.
+const maxCmdline = 256
+
+// addProcessInfo fills in p.Exe, p.Cmdline, p.UID, and p.Container for the
+// process that owns p.PID, best-effort. Errors reading /proc (the process
+// having already exited, permission denied, etc.) are ignored and simply
+// leave the corresponding field empty.
+func addProcessInfo(p *Port) {
+	if p.PID == 0 {
+		return
+	}
+	base := fmt.Sprintf("/proc/%d", p.PID)
+
+	if exe, err := os.Readlink(base + "/exe"); err == nil {
+		p.Exe = exe
+	}
+
+	if raw, err := os.ReadFile(base + "/cmdline"); err == nil {
+		if len(raw) > maxCmdline {
+			raw = raw[:maxCmdline]
+		}
+		args := bytes.Split(bytes.TrimRight(raw, "\x00"), []byte{0})
+		strArgs := make([]string, 0, len(args))
+		for _, a := range args {
+			if len(a) > 0 {
+				strArgs = append(strArgs, string(a))
+			}
+		}
+		p.Cmdline = strings.Join(strArgs, " ")
+	}
+
+	if st, err := os.Stat(base); err == nil {
+		p.UID = statUID(st)
+	}
+
+	p.Container = containerIDForPID(p.PID)
+
+	if exeSt, err := os.Stat(base + "/exe"); err == nil {
+		p.GoModule = goModuleForExe(base+"/exe", exeSt)
+	}
+}
+
+// containerCgroupPrefixes lists the substrings, for the common container
+// runtimes and Kubernetes CRI layouts, after which a process's
+// /proc/<pid>/cgroup entries carry the container ID or pod UID.
+var containerCgroupPrefixes = []string{
+	"/docker/",
+	"/docker-",
+	"/libpod-",     // Podman
+	"/crio-",       // CRI-O
+	"/containerd/", // bare containerd
+	"/kubepods",    // Kubernetes cgroup driver; ID is the last path element
+}
+
+// containerIDForPID reads /proc/<pid>/cgroup and extracts a container ID or
+// pod UID from the cgroup path, if the process appears to be running
+// inside a container. It returns "" for host processes.
+func containerIDForPID(pid int) string {
+	raw, err := os.ReadFile(fmt.Sprintf("/proc/%d/cgroup", pid))
+	if err != nil {
+		return ""
+	}
+	for _, line := range bytes.Split(raw, []byte("\n")) {
+		s := string(line)
+		for _, prefix := range containerCgroupPrefixes {
+			idx := strings.Index(s, prefix)
+			if idx == -1 {
+				continue
+			}
+			rest := s[idx+len(prefix):]
+			rest = strings.TrimSuffix(rest, ".scope")
+			// Take the trailing path component, which is the
+			// container/pod identifier regardless of how deep the
+			// cgroup hierarchy nests it.
+			if i := strings.LastIndexByte(rest, '/'); i != -1 {
+				rest = rest[i+1:]
+			}
+			rest = strings.TrimPrefix(rest, "docker-")
+			rest = strings.TrimSuffix(rest, ".scope")
+			if rest != "" {
+				return rest
+			}
+		}
+	}
+	return ""
+}
+
+// statUID extracts the owning UID from a os.FileInfo for a /proc/<pid>
+// directory. It's split out so the syscall.Stat_t type assertion lives in
+// one place.
+func statUID(fi os.FileInfo) uint32 {
+	st, ok := fi.Sys().(*syscall.Stat_t)
+	if !ok || st == nil {
+		return 0
+	}
+	return st.Uid
+}
+
+// goBuildInfoCacheSize bounds how many distinct binaries we keep parsed
+// build info for. Most hosts only have a handful of listening binaries
+// (nginx, a couple of Go services, sshd, ...), so this is generous
+// headroom rather than a tight budget.
+const goBuildInfoCacheSize = 256
+
+// goModuleCache caches goModuleForExe results keyed by the listening
+// binary's (dev, ino, mtime), so repeated polls of the same long-lived
+// process - or multiple ports owned by the same binary - only pay the cost
+// of opening and parsing the ELF once.
+var goModuleCache = newGoModuleLRU(goBuildInfoCacheSize)
+
+type goBuildInfoKey struct {
+	dev, ino uint64
+	mtime    int64
+}
+
+type goModuleLRU struct {
+	mu    sync.Mutex
+	cap   int
+	order *list.List // of *goModuleEntry, most recently used at front
+	elems map[goBuildInfoKey]*list.Element
+}
+
+type goModuleEntry struct {
+	key   goBuildInfoKey
+	value string
+}
+
+func newGoModuleLRU(cap int) *goModuleLRU {
+	return &goModuleLRU{
+		cap:   cap,
+		order: list.New(),
+		elems: make(map[goBuildInfoKey]*list.Element),
+	}
+}
+
+func (c *goModuleLRU) get(key goBuildInfoKey) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.elems[key]
+	if !ok {
+		return "", false
+	}
+	c.order.MoveToFront(e)
+	return e.Value.(*goModuleEntry).value, true
+}
+
+func (c *goModuleLRU) put(key goBuildInfoKey, value string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.elems[key]; ok {
+		e.Value.(*goModuleEntry).value = value
+		c.order.MoveToFront(e)
+		return
+	}
+	c.elems[key] = c.order.PushFront(&goModuleEntry{key: key, value: value})
+	if c.order.Len() > c.cap {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.elems, oldest.Value.(*goModuleEntry).key)
+	}
+}
+
+// goModuleForExe returns a "module@version" string describing the Go
+// binary at exePath, or "" if it can't be read or isn't a Go binary
+// (stripped, non-ELF, built without module info, etc). Results are cached
+// by (dev, ino, mtime); st must be the stat of exePath itself.
+func goModuleForExe(exePath string, st os.FileInfo) string {
+	sysSt, ok := st.Sys().(*syscall.Stat_t)
+	if !ok || sysSt == nil {
+		return readGoModule(exePath)
+	}
+	key := goBuildInfoKey{dev: uint64(sysSt.Dev), ino: sysSt.Ino, mtime: st.ModTime().UnixNano()}
+	if v, ok := goModuleCache.get(key); ok {
+		return v
+	}
+	v := readGoModule(exePath)
+	goModuleCache.put(key, v)
+	return v
+}
+
+// readGoModule extracts the main module's path and version from a Go
+// binary's embedded build info. It returns "" for non-Go or unreadable
+// binaries rather than erroring, since most listening processes aren't Go
+// programs at all.
+func readGoModule(path string) string {
+	bi, err := buildinfo.ReadFile(path)
+	if err != nil || bi.Main.Path == "" {
+		return ""
+	}
+	if bi.Main.Version == "" || bi.Main.Version == "(devel)" {
+		return bi.Main.Path
+	}
+	return bi.Main.Path + "@" + bi.Main.Version
+}
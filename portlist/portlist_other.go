@@ -0,0 +1,21 @@
+// Copyright (c) 2020 Tailscale Inc & AUTHORS All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build !linux || android
+
+package portlist
+
+import "errors"
+
+// errUnsupported is returned by listPorts on platforms this package doesn't
+// yet know how to enumerate listening sockets on.
+var errUnsupported = errors.New("portlist: not implemented on this platform")
+
+func listPorts() (List, error) {
+	return nil, errUnsupported
+}
+
+// addProcessInfo is a no-op here; process attribution (PID, Exe, Cmdline,
+// UID, Container, GoModule) is only implemented on Linux.
+func addProcessInfo(p *Port) {}
@@ -0,0 +1,68 @@
+// Copyright (c) 2020 Tailscale Inc & AUTHORS All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package tailcfg holds the wire-format types shared between tailscaled
+// and the control plane.
+package tailcfg
+
+// Hostinfo holds information about the host that's running a Tailscale
+// client, as self-reported by that client. Fields are omitted from the
+// wire encoding when zero.
+type Hostinfo struct {
+	OSVersion      string `json:",omitempty"` // "5.15.0-101-generic", etc
+	PackageType    string `json:",omitempty"` // "deb", "rpm", "snap", "source", etc
+	DistroName     string `json:",omitempty"` // "ubuntu", "centos", etc
+	DistroVersion  string `json:",omitempty"` // "22.04", "6.10", etc
+	DistroCodeName string `json:",omitempty"` // "jammy", etc
+	DeviceModel    string `json:",omitempty"` // "Raspberry Pi 4 Model B Rev 1.2", etc
+
+	LinuxFW *LinuxFW `json:",omitempty"`
+
+	// Warnings lists human-readable host configuration problems (e.g.
+	// "IPv4 forwarding is disabled") for the control plane and `tailscale
+	// debug hostinfo` to surface to an operator.
+	Warnings []string `json:",omitempty"`
+
+	// Hypervisor is the detected hypervisor the host is running under
+	// (e.g. "kvm", "vmware", "hyperv", "xen"), or "" on bare metal.
+	Hypervisor string `json:",omitempty"`
+	// Container is the detected container runtime (e.g. "docker", "lxc",
+	// "kubernetes"), or "" if the host isn't containerized.
+	Container string `json:",omitempty"`
+	// CloudProvider is the detected cloud platform (e.g. "aws", "gcp",
+	// "azure"), or "" if none was detected.
+	CloudProvider string `json:",omitempty"`
+
+	// GoVersion, GoModule, and VCSRevision describe the Go toolchain,
+	// main module, and VCS revision embedded in the running tailscaled
+	// binary's build info.
+	GoVersion   string `json:",omitempty"`
+	GoModule    string `json:",omitempty"`
+	VCSRevision string `json:",omitempty"`
+}
+
+// LinuxFW reports what was found probing the Linux host's firewall
+// subsystem: which backends (iptables, nftables) have rules installed, and
+// which firewall binaries are available on disk.
+type LinuxFW struct {
+	IPT     LinuxFWTypeInfo
+	NFT     LinuxFWTypeInfo
+	BinInfo map[string]LinuxFWBinInfo
+}
+
+// LinuxFWTypeInfo describes what was found probing one firewall backend.
+type LinuxFWTypeInfo struct {
+	NumRules     int
+	SyscallError int    // non-zero errno, if probing failed with a syscall error
+	OtherError   string // non-syscall error, if any
+}
+
+// LinuxFWBinInfo describes what was found running a firewall-related
+// binary (iptables, nft, ...) with --version.
+type LinuxFWBinInfo struct {
+	Present bool
+	Version string
+	Error   string
+	Flavor  string // e.g. "nf_tables", "legacy"
+}